@@ -1,3 +1,7 @@
+// Command kfmt converts Kubernetes-style resource quantities to
+// human-readable form, either as standalone numbers or as selected
+// fields inside JSON, NDJSON, YAML, or kubectl tabular input. See
+// pkg/kfmt for the underlying parsing and rewriting logic.
 package main
 
 import (
@@ -6,28 +10,63 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"regexp"
-	"strconv"
 	"strings"
+
+	"github.com/Flo4604/kfmt/pkg/kfmt"
+	"golang.org/x/text/language"
 )
 
 func main() {
 	fieldsFlag := flag.String("json-fields", "", "comma-separated list of JSON fields to convert")
+	countFieldsFlag := flag.String("count-fields", "", "comma-separated list of JSON fields to render with a thousands separator")
+	ordinalFieldsFlag := flag.String("ordinal-fields", "", "comma-separated list of JSON fields to render as English ordinals")
+	formatFlag := flag.String("format", "iec", "output format: iec, si, canonical, or raw")
+	inputFlag := flag.String("input", "json", "input mode: json, ndjson, yaml, or table")
+	spaceFlag := flag.Bool("space", false, "insert a space between the number and its unit")
+	groupFlag := flag.Bool("group", false, "insert a thousands separator into the integer part")
+	precisionFlag := flag.Int("precision", -1, "digits after the decimal point (default: magnitude-based heuristic)")
+	unitFlag := flag.String("unit", "", "force a specific unit (e.g. MiB, GB) instead of auto-selecting one")
+	localeFlag := flag.String("locale", "", "BCP 47 locale for decimal point and grouping symbols (e.g. de)")
 	flag.Parse()
 
-	var fields []string
-	if *fieldsFlag != "" {
-		fields = strings.Split(*fieldsFlag, ",")
-		for i := range fields {
-			fields[i] = strings.TrimSpace(fields[i])
+	if !validFormat(*formatFlag) {
+		fmt.Fprintf(os.Stderr, "error: unknown --format %q (want iec, si, canonical, or raw)\n", *formatFlag)
+		os.Exit(1)
+	}
+	if !validInput(*inputFlag) {
+		fmt.Fprintf(os.Stderr, "error: unknown --input %q (want json, ndjson, yaml, or table)\n", *inputFlag)
+		os.Exit(1)
+	}
+
+	opts := kfmt.FormatOptions{Space: *spaceFlag, Grouping: *groupFlag, Unit: *unitFlag}
+	if *precisionFlag >= 0 {
+		opts.Precision = precisionFlag
+	}
+	if *localeFlag != "" {
+		tag, err := language.Parse(*localeFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: invalid --locale %q: %v\n", *localeFlag, err)
+			os.Exit(1)
 		}
+		opts.Locale = tag
+	}
+
+	fields := splitFields(*fieldsFlag)
+	countFields := splitFields(*countFieldsFlag)
+	ordinalFields := splitFields(*ordinalFieldsFlag)
+	rw := kfmt.Rewriter{
+		Fields:        fields,
+		CountFields:   countFields,
+		OrdinalFields: ordinalFields,
+		Format:        *formatFlag,
+		Options:       opts,
 	}
 
 	// Check if we have arguments (direct number conversion)
 	args := flag.Args()
 	if len(args) > 0 {
 		for _, arg := range args {
-			result, err := formatValue(arg)
+			result, err := kfmt.Format(arg, *formatFlag, opts)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "error converting %s: %v\n", arg, err)
 				os.Exit(1)
@@ -43,168 +82,85 @@ func main() {
 		fmt.Fprintln(os.Stderr, "Usage:")
 		fmt.Fprintln(os.Stderr, "  kfmt <number>                            Convert bytes to human readable")
 		fmt.Fprintln(os.Stderr, "  <json> | kfmt --json-fields \"a,b\"        Convert specific JSON fields")
+		fmt.Fprintln(os.Stderr, "  <json> | kfmt --count-fields \"replicas\"  Comma-format plain numeric fields")
+		fmt.Fprintln(os.Stderr, "  <json> | kfmt --ordinal-fields \"rank\"    Render integer fields as ordinals")
+		fmt.Fprintln(os.Stderr, "  kfmt --format=canonical <number>         Emit the smallest exact quantity string")
+		fmt.Fprintln(os.Stderr, "  kubectl get pvc -o yaml | kfmt --input=yaml --json-fields \"capacity\"")
+		fmt.Fprintln(os.Stderr, "  kubectl get pvc            | kfmt --input=table")
 		os.Exit(1)
 	}
 
-	if len(fields) == 0 {
-		fmt.Fprintln(os.Stderr, "error: --json-fields is required when processing JSON")
+	// table mode rewrites recognized columns on its own; every other
+	// mode needs an explicit set of fields to act on.
+	if *inputFlag != "table" && len(fields) == 0 && len(countFields) == 0 && len(ordinalFields) == 0 {
+		fmt.Fprintln(os.Stderr, "error: --json-fields, --count-fields, or --ordinal-fields is required when processing JSON")
 		os.Exit(1)
 	}
 
-	// Process JSON from stdin
 	reader := bufio.NewReader(os.Stdin)
-	input, err := io.ReadAll(reader)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
-		os.Exit(1)
-	}
-
-	output := processJSON(string(input), fields)
-	fmt.Print(output)
-}
-
-func processJSON(input string, fields []string) string {
-	result := input
-	for _, field := range fields {
-		// Match "fieldName": "value" where value is a quantity.
-		// Quantities: integers, decimals, scientific notation, with optional suffix.
-		// Valid suffixes: Ki, Mi, Gi, Ti, Pi, Ei (binary) or k, K, M, G, T, P, E (decimal)
-		pattern := fmt.Sprintf(`"%s"\s*:\s*"([^"]+)"`, regexp.QuoteMeta(field))
-		re := regexp.MustCompile(pattern)
-		result = re.ReplaceAllStringFunc(result, func(match string) string {
-			submatch := re.FindStringSubmatch(match)
-			if len(submatch) < 2 {
-				return match
-			}
-			formatted, err := formatValue(submatch[1])
-			if err != nil {
-				return match // not a valid quantity, leave unchanged
-			}
-			return fmt.Sprintf(`"%s": "%s"`, field, formatted)
-		})
-
-		// Match "fieldName": 12345 or "fieldName": 1.5e6 (unquoted numbers)
-		pattern2 := fmt.Sprintf(`"%s"\s*:\s*(\d+\.?\d*(?:[eE][+-]?\d+)?)([,\s\n\r\}])`, regexp.QuoteMeta(field))
-		re2 := regexp.MustCompile(pattern2)
-		result = re2.ReplaceAllStringFunc(result, func(match string) string {
-			submatch := re2.FindStringSubmatch(match)
-			if len(submatch) < 3 {
-				return match
-			}
-			formatted, err := formatValue(submatch[1])
-			if err != nil {
-				return match
-			}
-			return fmt.Sprintf(`"%s": "%s"%s`, field, formatted, submatch[2])
-		})
-	}
-	return result
-}
 
-// parseQuantity parses a Kubernetes-style quantity string and returns bytes.
-// Supports:
-//   - Raw numbers: "1000", "1.5"
-//   - Scientific notation: "12e6", "1.5e9"
-//   - Binary suffixes (IEC): Ki, Mi, Gi, Ti, Pi, Ei
-//   - Decimal suffixes (SI): k, K, M, G, T, P, E
-func parseQuantity(s string) (uint64, error) {
-	s = strings.TrimSpace(s)
-	if s == "" {
-		return 0, fmt.Errorf("empty string")
-	}
-
-	// Handle scientific notation first (e.g., "12e6", "1.5e9")
-	if i := strings.IndexAny(s, "eE"); i >= 0 {
-		// Make sure this is scientific notation, not a suffix like "E" or "Ei"
-		// Scientific notation has digits after e/E
-		if i+1 < len(s) && (s[i+1] == '-' || s[i+1] == '+' || (s[i+1] >= '0' && s[i+1] <= '9')) {
-			f, err := strconv.ParseFloat(s, 64)
-			if err != nil {
-				return 0, err
-			}
-			if f < 0 {
-				return 0, fmt.Errorf("negative value")
-			}
-			return uint64(f), nil
+	switch *inputFlag {
+	case "ndjson":
+		if err := rw.StreamNDJSON(reader, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
+			os.Exit(1)
 		}
-	}
-
-	// Try suffixes in order: binary first (longer), then decimal
-	type suffixDef struct {
-		suffix     string
-		multiplier float64
-	}
-	suffixes := []suffixDef{
-		// Binary (IEC)
-		{"Ki", 1024},
-		{"Mi", 1024 * 1024},
-		{"Gi", 1024 * 1024 * 1024},
-		{"Ti", 1024 * 1024 * 1024 * 1024},
-		{"Pi", 1024 * 1024 * 1024 * 1024 * 1024},
-		{"Ei", 1024 * 1024 * 1024 * 1024 * 1024 * 1024},
-		// Decimal (SI)
-		{"k", 1000},
-		{"K", 1000},
-		{"M", 1000 * 1000},
-		{"G", 1000 * 1000 * 1000},
-		{"T", 1000 * 1000 * 1000 * 1000},
-		{"P", 1000 * 1000 * 1000 * 1000 * 1000},
-		{"E", 1000 * 1000 * 1000 * 1000 * 1000 * 1000},
-	}
-
-	for _, sf := range suffixes {
-		if strings.HasSuffix(s, sf.suffix) {
-			numStr := strings.TrimSuffix(s, sf.suffix)
-			f, err := strconv.ParseFloat(numStr, 64)
-			if err != nil {
-				return 0, err
-			}
-			if f < 0 {
-				return 0, fmt.Errorf("negative value")
-			}
-			return uint64(f * sf.multiplier), nil
+	case "yaml":
+		input, err := io.ReadAll(reader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
+			os.Exit(1)
+		}
+		output, err := rw.RewriteYAML(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Print(output)
+	case "table":
+		input, err := io.ReadAll(reader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(rw.RewriteTable(string(input)))
+	default: // "json"
+		input, err := io.ReadAll(reader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error reading stdin: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(rw.RewriteJSON(string(input)))
 	}
+}
 
-	// No suffix, parse as raw bytes
-	f, err := strconv.ParseFloat(s, 64)
-	if err != nil {
-		return 0, err
+// splitFields turns a comma-separated flag value into a trimmed slice of
+// field selectors, or nil if csv is empty.
+func splitFields(csv string) []string {
+	if csv == "" {
+		return nil
 	}
-	if f < 0 {
-		return 0, fmt.Errorf("negative value")
+	fields := strings.Split(csv, ",")
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
 	}
-	return uint64(f), nil
+	return fields
 }
 
-func formatValue(s string) (string, error) {
-	bytes, err := parseQuantity(s)
-	if err != nil {
-		return "", err
+func validFormat(format string) bool {
+	switch format {
+	case "iec", "si", "canonical", "raw":
+		return true
+	default:
+		return false
 	}
-	return humanizeIEC(bytes), nil
 }
 
-func humanizeIEC(bytes uint64) string {
-	const unit = 1024
-	if bytes < unit {
-		return fmt.Sprintf("%dB", bytes)
-	}
-
-	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
-	exp := 0
-	val := float64(bytes)
-
-	for val >= unit && exp < len(units)-1 {
-		val /= unit
-		exp++
-	}
-
-	// Format with appropriate precision
-	if val >= 100 {
-		return fmt.Sprintf("%.0f%s", val, units[exp])
-	} else if val >= 10 {
-		return fmt.Sprintf("%.1f%s", val, units[exp])
+func validInput(input string) bool {
+	switch input {
+	case "json", "ndjson", "yaml", "table":
+		return true
+	default:
+		return false
 	}
-	return fmt.Sprintf("%.2f%s", val, units[exp])
 }
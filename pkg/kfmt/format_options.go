@@ -0,0 +1,178 @@
+package kfmt
+
+import (
+	"math/big"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// iecUnits and siUnits are the unit tables consulted by humanize, and by
+// FormatOptions.Unit to recognize a forced unit.
+var (
+	iecUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	siUnits  = []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"}
+)
+
+// FormatOptions controls how Quantity.HumanIEC and Quantity.HumanSI
+// render a byte count: unit spacing, thousands grouping, precision, a
+// forced unit, and locale-specific separator symbols. The zero value
+// reproduces the original, unconfigured rendering (no space, no
+// grouping, the three-tier precision heuristic, auto-selected unit,
+// "." decimal point).
+type FormatOptions struct {
+	// Space inserts a space between the number and its unit, e.g.
+	// "1.50 GiB" instead of "1.50GiB".
+	Space bool
+	// Grouping inserts a thousands separator into the integer part,
+	// e.g. "1,048,576 B" or (with Locale set to German) "1.048.576 B".
+	Grouping bool
+	// Precision, if non-nil, forces this many digits after the decimal
+	// point. A nil Precision uses the three-tier heuristic: 0 digits
+	// at 100+ units, 1 digit at 10+, 2 digits below that, and 0 digits
+	// when no unit suffix applies (a raw byte count).
+	Precision *int
+	// Unit, if it names one of the unit table's suffixes (e.g. "MiB"
+	// for HumanIEC, "GB" for HumanSI), forces reporting in that unit
+	// instead of auto-selecting the largest one that keeps the value
+	// >= 1. An unrecognized Unit is ignored and falls back to
+	// auto-selection.
+	Unit string
+	// Locale selects the decimal point and grouping separator symbols.
+	// The zero value (language.Und) uses "." and ",". Only locales whose
+	// base language is German ("de") have dedicated symbols (see
+	// localeSymbols); others fall back to the default.
+	Locale language.Tag
+}
+
+// humanize renders bytes (an arbitrary-precision, non-negative byte
+// count) using units (indexed by power of base), applying opts for unit
+// selection, precision, spacing, grouping, and locale. bytes is never
+// converted to a machine word, so magnitudes beyond uint64 (e.g.
+// "999999999999999999Ei") render correctly instead of silently
+// wrapping.
+func humanize(bytes *big.Int, units []string, base int64, opts FormatOptions) string {
+	forced := -1
+	if opts.Unit != "" {
+		forced = unitIndex(units, opts.Unit)
+	}
+
+	exp := 0
+	val := new(big.Rat).SetInt(bytes)
+	baseRat := new(big.Rat).SetInt64(base)
+	if forced < 0 {
+		for val.Cmp(baseRat) >= 0 && exp < len(units)-1 {
+			val.Quo(val, baseRat)
+			exp++
+		}
+	} else {
+		exp = forced
+		for i := 0; i < exp; i++ {
+			val.Quo(val, baseRat)
+		}
+	}
+
+	numStr := formatNumber(val, precisionFor(val, exp, opts.Precision), opts)
+	if opts.Space {
+		return numStr + " " + units[exp]
+	}
+	return numStr + units[exp]
+}
+
+// precisionFor picks the number of digits after the decimal point: a
+// forced precision wins outright, a raw byte count (exp == 0) always
+// gets none, and otherwise the magnitude of val picks from the
+// three-tier heuristic.
+func precisionFor(val *big.Rat, exp int, forced *int) int {
+	if forced != nil {
+		return *forced
+	}
+	if exp == 0 {
+		return 0
+	}
+	switch {
+	case val.Cmp(big.NewRat(100, 1)) >= 0:
+		return 0
+	case val.Cmp(big.NewRat(10, 1)) >= 0:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// formatNumber renders val with prec digits after the decimal point,
+// then applies opts.Grouping and opts.Locale's separator symbols. val
+// is rendered exactly (rounded only at the requested precision), never
+// through float64.
+func formatNumber(val *big.Rat, prec int, opts FormatOptions) string {
+	s := val.FloatString(prec)
+
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+
+	decimalSep, groupSep := localeSymbols(opts.Locale)
+	if opts.Grouping {
+		intPart = groupDigits(intPart, groupSep)
+	}
+
+	out := intPart
+	if fracPart != "" {
+		out += decimalSep + fracPart
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
+
+// groupDigits inserts sep into digits every three characters from the
+// right, e.g. groupDigits("1048576", ",") == "1,048,576".
+func groupDigits(digits string, sep string) string {
+	n := len(digits)
+	if n <= 3 {
+		return digits
+	}
+
+	lead := n % 3
+	if lead == 0 {
+		lead = 3
+	}
+
+	var sb strings.Builder
+	sb.WriteString(digits[:lead])
+	for i := lead; i < n; i += 3 {
+		sb.WriteString(sep)
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
+
+// localeSymbols returns the decimal point and grouping separator for
+// tag. This is a small, hand-picked table rather than a full CLDR
+// implementation — unrecognized locales fall back to "." and ",".
+func localeSymbols(tag language.Tag) (decimalSep, groupSep string) {
+	base, _ := tag.Base()
+	switch base.String() {
+	case "de":
+		return ",", "."
+	default:
+		return ".", ","
+	}
+}
+
+// unitIndex returns the index of name in units, or -1 if it's not one
+// of them.
+func unitIndex(units []string, name string) int {
+	for i, u := range units {
+		if u == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,659 @@
+package kfmt
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestHumanizeIEC(t *testing.T) {
+	tests := []struct {
+		bytes uint64
+		want  string
+	}{
+		{0, "0B"},
+		{1, "1B"},
+		{512, "512B"},
+		{1023, "1023B"},
+		{1024, "1.00KiB"},
+		{1536, "1.50KiB"},
+		{10240, "10.0KiB"},
+		{102400, "100KiB"},
+		{1048576, "1.00MiB"},
+		{178255984, "170MiB"},
+		{293007, "286KiB"},
+		{128849018, "123MiB"},
+		{1073741824, "1.00GiB"},
+		{10737418240, "10.0GiB"},
+		{1099511627776, "1.00TiB"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			got := humanize(new(big.Int).SetUint64(tc.bytes), iecUnits, 1024, FormatOptions{})
+			if got != tc.want {
+				t.Errorf("humanize(%d) = %q, want %q", tc.bytes, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestHumanIECBeyondUint64(t *testing.T) {
+	q, err := Parse("999999999999999999Ei")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got := q.HumanIEC(FormatOptions{})
+	want := "999999999999999999EiB"
+	if got != want {
+		t.Errorf("HumanIEC() = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    uint64
+		wantErr bool
+	}{
+		// Raw bytes
+		{"178255984", 178255984, false},
+		{"0", 0, false},
+		{"1024", 1024, false},
+
+		// Binary suffixes (IEC)
+		{"1Ki", 1024, false},
+		{"1Mi", 1048576, false},
+		{"1Gi", 1073741824, false},
+		{"1Ti", 1099511627776, false},
+		{"12075408Ki", 12365217792, false},
+		{"100Mi", 104857600, false},
+
+		// Decimal suffixes (SI)
+		{"1K", 1000, false},
+		{"1k", 1000, false},
+		{"1M", 1000000, false},
+		{"1G", 1000000000, false},
+		{"500M", 500000000, false},
+
+		// Scientific notation (e-notation)
+		{"1e3", 1000, false},
+		{"12e6", 12000000, false},
+		{"1.5e9", 1500000000, false},
+		{"1.0e6", 1000000, false},
+		{"2.5e3", 2500, false},
+
+		// Decimal values with binary suffixes
+		{"1.5Ki", 1536, false},
+		{"1.5Mi", 1572864, false},
+		{"1.5Gi", 1610612736, false},
+		{"2.5Gi", 2684354560, false},
+		{"0.5Mi", 524288, false},
+
+		// Decimal values with decimal suffixes
+		{"1.5k", 1500, false},
+		{"1.5M", 1500000, false},
+		{"2.5G", 2500000000, false},
+
+		// Higher unit suffixes (Ti, Pi, Ei)
+		{"1Pi", 1125899906842624, false},
+		{"1.5Ti", 1649267441664, false},
+		{"1T", 1000000000000, false},
+		{"1P", 1000000000000000, false},
+
+		// Scientific notation edge cases
+		{"1e+3", 1000, false},
+		{"1.0e+6", 1000000, false},
+
+		// Raw decimal bytes (no suffix)
+		{"1.5", 1, false}, // truncates to 1 byte
+		{"100.9", 100, false},
+
+		// Whitespace handling
+		{" 1Ki ", 1024, false},
+		{"  1024  ", 1024, false},
+
+		// Very small values (truncate to 0)
+		{"1e-3", 0, false}, // 0.001 truncates to 0 bytes
+		{"0.001", 0, false},
+
+		// Errors
+		{"", 0, true},
+		{"invalid", 0, true},
+		{"Ki", 0, true},
+		{"-1", 0, true},     // negative value
+		{"-100Mi", 0, true}, // negative with suffix
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			q, err := Parse(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Parse(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+				return
+			}
+			if err == nil && q.Uint64() != tc.want {
+				t.Errorf("Parse(%q) = %d, want %d", tc.input, q.Uint64(), tc.want)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		// Raw bytes
+		{"178255984", "170MiB", false},
+		{"293007", "286KiB", false},
+		{"1073741824", "1.00GiB", false},
+
+		// Binary suffixes
+		{"12075408Ki", "11.5GiB", false},
+		{"100Mi", "100MiB", false},
+		{"1Gi", "1.00GiB", false},
+
+		// Decimal suffixes (converted to binary display)
+		{"1G", "954MiB", false},
+		{"500M", "477MiB", false},
+
+		// Scientific notation (e-notation)
+		{"1.5e9", "1.40GiB", false},
+		{"12e6", "11.4MiB", false},
+		{"1e3", "1000B", false},
+
+		// Decimal values with binary suffixes
+		{"1.5Gi", "1.50GiB", false},
+		{"1.5Mi", "1.50MiB", false},
+		{"1.5Ki", "1.50KiB", false},
+		{"2.5Gi", "2.50GiB", false},
+
+		// Decimal values with decimal suffixes
+		{"1.5M", "1.43MiB", false},
+		{"2.5G", "2.33GiB", false},
+
+		// Higher unit suffixes
+		{"1Ti", "1.00TiB", false},
+		{"1.5Ti", "1.50TiB", false},
+
+		// Raw decimal bytes
+		{"1.5", "1B", false},
+		{"100.9", "100B", false},
+
+		// Errors
+		{"invalid", "", true},
+		{"", "", true},
+		{"-1", "", true},
+		{"-100Mi", "", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := Format(tc.input, "iec", FormatOptions{})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Format(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+				return
+			}
+			if got != tc.want {
+				t.Errorf("Format(%q) = %q, want %q", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRewriterRewriteJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		fields []string
+		want   string
+	}{
+		{
+			name:   "single field quoted",
+			input:  `{"usedBytes": "178255984"}`,
+			fields: []string{"usedBytes"},
+			want:   `{"usedBytes": "170MiB"}`,
+		},
+		{
+			name:   "multiple fields",
+			input:  `{"usedBytes": "178255984", "growthRate": "293007"}`,
+			fields: []string{"usedBytes", "growthRate"},
+			want:   `{"usedBytes": "170MiB", "growthRate": "286KiB"}`,
+		},
+		{
+			name:   "nested object",
+			input:  `{"data": {"usedBytes": "178255984"}}`,
+			fields: []string{"usedBytes"},
+			want:   `{"data": {"usedBytes": "170MiB"}}`,
+		},
+		{
+			name:   "unquoted number",
+			input:  `{"usedBytes": 178255984}`,
+			fields: []string{"usedBytes"},
+			want:   `{"usedBytes": "170MiB"}`,
+		},
+		{
+			name:   "field not in list",
+			input:  `{"otherField": "178255984"}`,
+			fields: []string{"usedBytes"},
+			want:   `{"otherField": "178255984"}`,
+		},
+		{
+			name:   "preserves other fields",
+			input:  `{"usedBytes": "178255984", "name": "test", "count": 42}`,
+			fields: []string{"usedBytes"},
+			want:   `{"usedBytes": "170MiB", "name": "test", "count": 42}`,
+		},
+		{
+			name:   "empty fields list",
+			input:  `{"usedBytes": "178255984"}`,
+			fields: []string{},
+			want:   `{"usedBytes": "178255984"}`,
+		},
+		{
+			name:   "kubernetes quantity Ki suffix",
+			input:  `{"spaceAvailable": "12075408Ki"}`,
+			fields: []string{"spaceAvailable"},
+			want:   `{"spaceAvailable": "11.5GiB"}`,
+		},
+		{
+			name:   "kubernetes quantity Mi suffix",
+			input:  `{"capacity": "100Mi"}`,
+			fields: []string{"capacity"},
+			want:   `{"capacity": "100MiB"}`,
+		},
+		{
+			name:   "kubernetes quantity Gi suffix",
+			input:  `{"size": "12Gi"}`,
+			fields: []string{"size"},
+			want:   `{"size": "12.0GiB"}`,
+		},
+		{
+			name:   "mixed raw and suffixed",
+			input:  `{"usedBytes": "178255984", "spaceAvailable": "12075408Ki"}`,
+			fields: []string{"usedBytes", "spaceAvailable"},
+			want:   `{"usedBytes": "170MiB", "spaceAvailable": "11.5GiB"}`,
+		},
+		{
+			name:   "scientific notation quoted",
+			input:  `{"size": "1.5e9"}`,
+			fields: []string{"size"},
+			want:   `{"size": "1.40GiB"}`,
+		},
+		{
+			name:   "scientific notation unquoted",
+			input:  `{"size": 1.5e9}`,
+			fields: []string{"size"},
+			want:   `{"size": "1.40GiB"}`,
+		},
+		{
+			name:   "decimal with binary suffix",
+			input:  `{"capacity": "1.5Gi"}`,
+			fields: []string{"capacity"},
+			want:   `{"capacity": "1.50GiB"}`,
+		},
+		{
+			name:   "decimal with decimal suffix",
+			input:  `{"rate": "1.5M"}`,
+			fields: []string{"rate"},
+			want:   `{"rate": "1.43MiB"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rw := Rewriter{Fields: tc.fields, Format: "iec"}
+			got := rw.RewriteJSON(tc.input)
+			if got != tc.want {
+				t.Errorf("RewriteJSON() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRewriterRewriteJSONPathSelectors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		fields []string
+		want   string
+	}{
+		{
+			name:   "exact dotted path",
+			input:  `{"spec": {"resources": {"requests": {"memory": "256Mi", "cpu": "100m"}}}}`,
+			fields: []string{"spec.resources.requests.memory"},
+			want:   `{"spec": {"resources": {"requests": {"memory": "256MiB", "cpu": "100m"}}}}`,
+		},
+		{
+			name:   "dotted path does not match same field at other depths",
+			input:  `{"memory": "256Mi", "spec": {"resources": {"requests": {"memory": "256Mi"}}}}`,
+			fields: []string{"spec.resources.requests.memory"},
+			want:   `{"memory": "256Mi", "spec": {"resources": {"requests": {"memory": "256MiB"}}}}`,
+		},
+		{
+			name:   "object wildcard",
+			input:  `{"status": {"nodeA": {"usedBytes": "1Gi"}, "nodeB": {"usedBytes": "2Gi"}}}`,
+			fields: []string{"status.*.usedBytes"},
+			want:   `{"status": {"nodeA": {"usedBytes": "1.00GiB"}, "nodeB": {"usedBytes": "2.00GiB"}}}`,
+		},
+		{
+			name:   "array wildcard",
+			input:  `{"items": [{"capacity": "10Gi"}, {"capacity": "20Gi"}]}`,
+			fields: []string{"items[*].capacity"},
+			want:   `{"items": [{"capacity": "10.0GiB"}, {"capacity": "20.0GiB"}]}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rw := Rewriter{Fields: tc.fields, Format: "iec"}
+			got := rw.RewriteJSON(tc.input)
+			if got != tc.want {
+				t.Errorf("RewriteJSON() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMilliAndRational(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    uint64
+		wantErr bool
+	}{
+		{"1500m", 1, false}, // 1.5 bytes, truncates to 1
+		{"1000m", 1, false}, // exactly 1 byte
+		{"500m", 0, false},  // 0.5 bytes, truncates to 0
+		{"1e3Ki", 0, true},  // exponent not allowed with a binary suffix
+		{"1e3Mi", 0, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			q, err := Parse(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Parse(%q) error = %v, wantErr %v", tc.input, err, tc.wantErr)
+				return
+			}
+			if err == nil && q.Uint64() != tc.want {
+				t.Errorf("Parse(%q) = %d, want %d", tc.input, q.Uint64(), tc.want)
+			}
+		})
+	}
+}
+
+func TestParseExactPrecision(t *testing.T) {
+	// 12075408Ki is 12075408 * 1024 bytes; big.Rat must keep the result
+	// an exact integer.
+	q, err := Parse("12075408Ki")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := new(big.Int).Mul(big.NewInt(12075408), big.NewInt(1024))
+	if q.rat.Num().Cmp(want) != 0 || !q.rat.IsInt() {
+		t.Errorf("Parse(\"12075408Ki\") = %s, want %s", q.rat.Num(), want)
+	}
+}
+
+func TestFormatQuantity(t *testing.T) {
+	tests := []struct {
+		input  string
+		format string
+		want   string
+	}{
+		{"1073741824", "iec", "1.00GiB"},
+		{"1000000000", "si", "1.00GB"},
+		{"1500000", "si", "1.50MB"},
+		{"1048576", "canonical", "1Mi"},
+		{"1536", "canonical", "1536"},
+		{"1000", "canonical", "1k"},
+		{"0", "canonical", "0"},
+		{"0Ki", "canonical", "0"},
+		{"178255984", "raw", "178255984"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.format+"/"+tc.input, func(t *testing.T) {
+			got, err := Format(tc.input, tc.format, FormatOptions{})
+			if err != nil {
+				t.Fatalf("Format(%q, %q): %v", tc.input, tc.format, err)
+			}
+			if got != tc.want {
+				t.Errorf("Format(%q, %q) = %q, want %q", tc.input, tc.format, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatQuantityUnknownFormat(t *testing.T) {
+	if _, err := Format("1024", "bogus", FormatOptions{}); err == nil {
+		t.Error("Format with unknown format: expected error, got nil")
+	}
+}
+
+func TestFormatOptions(t *testing.T) {
+	q, err := Parse("1048576") // 1Mi
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	t.Run("space", func(t *testing.T) {
+		got := q.HumanIEC(FormatOptions{Space: true})
+		if want := "1.00 MiB"; got != want {
+			t.Errorf("HumanIEC(Space) = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("forced precision", func(t *testing.T) {
+		prec := 3
+		got := q.HumanIEC(FormatOptions{Precision: &prec})
+		if want := "1.000MiB"; got != want {
+			t.Errorf("HumanIEC(Precision=3) = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("forced unit", func(t *testing.T) {
+		// Forcing a smaller unit than auto-selection would pick still
+		// runs the same magnitude-based precision heuristic, so a
+		// large value in that unit gets zero decimal places.
+		got := q.HumanIEC(FormatOptions{Unit: "KiB"})
+		if want := "1024KiB"; got != want {
+			t.Errorf("HumanIEC(Unit=KiB) = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("grouping on a raw byte count", func(t *testing.T) {
+		got := q.HumanIEC(FormatOptions{Grouping: true, Unit: "B"})
+		if want := "1,048,576B"; got != want {
+			t.Errorf("HumanIEC(Grouping, Unit=B) = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("german locale", func(t *testing.T) {
+		tag, err := language.Parse("de")
+		if err != nil {
+			t.Fatalf("language.Parse: %v", err)
+		}
+		got := q.HumanIEC(FormatOptions{Space: true, Grouping: true, Unit: "B", Locale: tag})
+		if want := "1.048.576 B"; got != want {
+			t.Errorf("HumanIEC(de locale) = %q, want %q", got, want)
+		}
+	})
+}
+
+func TestRewriterStreamNDJSON(t *testing.T) {
+	input := strings.NewReader(
+		`{"usedBytes": "178255984"}` + "\n" +
+			"\n" + // blank lines are skipped
+			`{"usedBytes": "1Gi"}` + "\n",
+	)
+	var out bytes.Buffer
+
+	rw := Rewriter{Fields: []string{"usedBytes"}, Format: "iec"}
+	if err := rw.StreamNDJSON(input, &out); err != nil {
+		t.Fatalf("StreamNDJSON: %v", err)
+	}
+
+	want := `{"usedBytes": "170MiB"}` + "\n" + `{"usedBytes": "1.00GiB"}` + "\n"
+	if out.String() != want {
+		t.Errorf("StreamNDJSON() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRewriterRewriteTable(t *testing.T) {
+	input := "NAME      CAPACITY    STATUS\n" +
+		"pvc-a     12075408Ki  Bound\n" +
+		"pvc-b     1Gi         Bound\n"
+
+	rw := Rewriter{Format: "iec"}
+	got := rw.RewriteTable(input)
+
+	lines := strings.Split(got, "\n")
+	if !strings.Contains(lines[1], "11.5GiB") {
+		t.Errorf("row 1 CAPACITY not rewritten: %q", lines[1])
+	}
+	if !strings.Contains(lines[1], "Bound") {
+		t.Errorf("row 1 STATUS column lost: %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "1.00GiB") {
+		t.Errorf("row 2 CAPACITY not rewritten: %q", lines[2])
+	}
+	if !strings.HasPrefix(got, "NAME") {
+		t.Errorf("header row should be left untouched, got %q", lines[0])
+	}
+}
+
+func TestRewriterRewriteTableOverflowsColumn(t *testing.T) {
+	input := "NAME      CAPACITY        STATUS\n" +
+		"pvc-a     999999999999Ki  Bound\n"
+
+	rw := Rewriter{Format: "raw", Options: FormatOptions{Grouping: true}}
+	got := rw.RewriteTable(input)
+
+	line := strings.Split(got, "\n")[1]
+	if !strings.Contains(line, "Bound") {
+		t.Fatalf("STATUS column lost: %q", line)
+	}
+	if strings.Contains(line, "Bound") && !strings.Contains(line, " Bound") {
+		t.Errorf("rewritten CAPACITY ran straight into STATUS with no separator: %q", line)
+	}
+}
+
+func TestRewriterRewriteYAML(t *testing.T) {
+	input := []byte("capacity: 12075408Ki\nname: pvc-a\n")
+
+	rw := Rewriter{Fields: []string{"capacity"}, Format: "iec"}
+	got, err := rw.RewriteYAML(input)
+	if err != nil {
+		t.Fatalf("RewriteYAML: %v", err)
+	}
+
+	want := `{"capacity": "11.5GiB", "name": "pvc-a"}`
+	if got != want {
+		t.Errorf("RewriteYAML() = %q, want %q", got, want)
+	}
+}
+
+func TestComma(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"1234567", "1,234,567"},
+		{"123", "123"},
+		{"0", "0"},
+		{"-1234.5", "-1,234.5"},
+		{"+42000", "42,000"},
+		{"1000000.25", "1,000,000.25"},
+	}
+	for _, tc := range tests {
+		got, err := Comma(tc.in)
+		if err != nil {
+			t.Errorf("Comma(%q) returned error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Comma(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestCommaInvalid(t *testing.T) {
+	for _, in := range []string{"", "12a4", "1Gi"} {
+		if _, err := Comma(in); err == nil {
+			t.Errorf("Comma(%q): expected error, got none", in)
+		}
+	}
+}
+
+func TestOrdinal(t *testing.T) {
+	tests := []struct {
+		in   int
+		want string
+	}{
+		{1, "1st"},
+		{2, "2nd"},
+		{3, "3rd"},
+		{4, "4th"},
+		{11, "11th"},
+		{12, "12th"},
+		{13, "13th"},
+		{21, "21st"},
+		{22, "22nd"},
+		{23, "23rd"},
+		{111, "111th"},
+		{0, "0th"},
+	}
+	for _, tc := range tests {
+		if got := Ordinal(tc.in); got != tc.want {
+			t.Errorf("Ordinal(%d) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestRewriterRewriteJSONCountAndOrdinalFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rw    Rewriter
+		want  string
+	}{
+		{
+			name:  "count field",
+			input: `{"restartCount": 1234567}`,
+			rw:    Rewriter{CountFields: []string{"restartCount"}},
+			want:  `{"restartCount": "1,234,567"}`,
+		},
+		{
+			name:  "ordinal field",
+			input: `{"rank": 22}`,
+			rw:    Rewriter{OrdinalFields: []string{"rank"}},
+			want:  `{"rank": "22nd"}`,
+		},
+		{
+			name:  "quantity, count, and ordinal fields together",
+			input: `{"usedBytes": "178255984", "replicas": 3000, "rank": 1}`,
+			rw: Rewriter{
+				Fields:        []string{"usedBytes"},
+				CountFields:   []string{"replicas"},
+				OrdinalFields: []string{"rank"},
+				Format:        "iec",
+			},
+			want: `{"usedBytes": "170MiB", "replicas": "3,000", "rank": "1st"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.rw.RewriteJSON(tc.input)
+			if got != tc.want {
+				t.Errorf("RewriteJSON() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
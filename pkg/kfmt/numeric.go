@@ -0,0 +1,67 @@
+package kfmt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Comma formats s — a plain integer or decimal number, optionally
+// signed — with a thousands separator inserted into the integer part,
+// e.g. "1234567" -> "1,234,567" or "-1234.5" -> "-1,234.5". Unlike
+// Format/Parse, s is not interpreted as a Quantity: unit suffixes,
+// exponents, and the milli suffix aren't recognized, since this is
+// meant for plain counters (replicas, restartCount) rather than byte
+// sizes.
+func Comma(s string) (string, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	intPart, fracPart := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, fracPart = s[:i], s[i+1:]
+	}
+	if intPart == "" {
+		return "", fmt.Errorf("invalid number %q", orig)
+	}
+	for _, c := range intPart + fracPart {
+		if c < '0' || c > '9' {
+			return "", fmt.Errorf("invalid number %q", orig)
+		}
+	}
+
+	out := groupDigits(intPart, ",")
+	if fracPart != "" {
+		out += "." + fracPart
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out, nil
+}
+
+// Ordinal renders n as an English ordinal, e.g. 1 -> "1st", 2 -> "2nd",
+// 3 -> "3rd", 11 -> "11th", 22 -> "22nd". n is expected to be
+// non-negative; ordinals have no established negative form.
+func Ordinal(n int) string {
+	suffix := "th"
+	if r := n % 100; r < 11 || r > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
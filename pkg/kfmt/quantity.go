@@ -0,0 +1,313 @@
+// Package kfmt parses Kubernetes-style resource.Quantity strings and
+// renders them in human-readable or canonical form, and rewrites
+// quantity-shaped values found inside JSON, YAML, NDJSON, or kubectl
+// tabular output. It is the library behind the kfmt CLI.
+package kfmt
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Quantity is an exact, arbitrary-precision byte count, backed by a
+// math/big.Rat. Values like "1234e-3" or "999999999999999999Ei" don't
+// round-trip through float64 without losing precision; Quantity preserves them
+// exactly until a caller asks for a truncated or approximate view.
+type Quantity struct {
+	rat *big.Rat
+}
+
+// WholeBytes truncates q toward zero to a whole number of bytes, as an
+// arbitrary-precision integer. Unlike Uint64, it never loses magnitude
+// for quantities beyond the uint64 range, e.g. "999999999999999999Ei".
+func (q Quantity) WholeBytes() *big.Int {
+	return new(big.Int).Quo(q.rat.Num(), q.rat.Denom())
+}
+
+// Uint64 truncates q toward zero to a whole number of bytes, clamped to
+// [0, math.MaxUint64] if q doesn't fit — e.g. a huge value like
+// "999999999999999999Ei" clamps to math.MaxUint64 rather than silently
+// wrapping (the behavior of big.Int.Uint64 on an out-of-range value is
+// undefined). Callers that need the true magnitude of such a value
+// should use WholeBytes instead.
+func (q Quantity) Uint64() uint64 {
+	whole := q.WholeBytes()
+	if whole.Sign() < 0 {
+		return 0
+	}
+	if !whole.IsUint64() {
+		return math.MaxUint64
+	}
+	return whole.Uint64()
+}
+
+// HumanIEC renders q using binary (base-1024) units, e.g. "1.50GiB".
+// opts controls spacing, grouping, precision, forced unit, and locale;
+// the zero value reproduces the plain default rendering.
+func (q Quantity) HumanIEC(opts FormatOptions) string {
+	return humanize(q.WholeBytes(), iecUnits, 1024, opts)
+}
+
+// HumanSI renders q using decimal (base-1000) units, e.g. "1.50GB". opts
+// is as for HumanIEC.
+func (q Quantity) HumanSI(opts FormatOptions) string {
+	return humanize(q.WholeBytes(), siUnits, 1000, opts)
+}
+
+// String renders an integral q as the smallest exact quantity string,
+// Kubernetes-style: the largest suffix (binary preferred over decimal) that
+// divides q evenly, e.g. 1048576 -> "1Mi", 1536 -> "1536" (not evenly
+// divisible by any suffix, so the plain integer is already the smallest
+// exact form). A non-integral q is rendered as a plain decimal rounded to
+// three places, e.g. "1500m" -> "1.500"; no milli suffix is emitted, and
+// any fraction finer than 0.001 is rounded away.
+func (q Quantity) String() string {
+	return canonicalQuantity(q)
+}
+
+// Raw renders q as a plain number of bytes, with no unit suffix.
+func (q Quantity) Raw() string {
+	if q.rat.IsInt() {
+		return q.rat.Num().String()
+	}
+	return q.rat.FloatString(3)
+}
+
+// Parse parses a Kubernetes-style quantity string. Supports:
+//   - Raw numbers: "1000", "1.5"
+//   - Scientific notation: "12e6", "1.5e9", "1234e-3"
+//   - Binary suffixes (IEC): Ki, Mi, Gi, Ti, Pi, Ei
+//   - Decimal suffixes (SI): k, K, M, G, T, P, E
+//   - The milli suffix: "m" (value * 1e-3), e.g. "1500m" == 1.5
+//
+// An exponent combined with a binary suffix (e.g. "1e3Ki") is rejected,
+// matching upstream resource.Quantity: DecimalExponent and BinarySI are
+// mutually exclusive formats. All arithmetic is done with big.Rat, so
+// "12075408Ki" and similar large or fractional values never round-trip
+// through float64.
+func Parse(s string) (Quantity, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Quantity{}, fmt.Errorf("empty string")
+	}
+
+	binarySuffixes := []string{"Ki", "Mi", "Gi", "Ti", "Pi", "Ei"}
+	if hasExponent(s) {
+		for _, suf := range binarySuffixes {
+			if strings.HasSuffix(s, suf) {
+				return Quantity{}, fmt.Errorf("%q: exponents are not allowed with binary suffix %q", s, suf)
+			}
+		}
+	}
+
+	type suffixDef struct {
+		suffix     string
+		multiplier *big.Rat
+	}
+	suffixes := []suffixDef{
+		// Binary (IEC)
+		{"Ki", big.NewRat(1024, 1)},
+		{"Mi", big.NewRat(1024*1024, 1)},
+		{"Gi", big.NewRat(1024*1024*1024, 1)},
+		{"Ti", big.NewRat(1024*1024*1024*1024, 1)},
+		{"Pi", big.NewRat(1024*1024*1024*1024*1024, 1)},
+		{"Ei", big.NewRat(1024*1024*1024*1024*1024*1024, 1)},
+		// Decimal (SI)
+		{"k", big.NewRat(1000, 1)},
+		{"K", big.NewRat(1000, 1)},
+		{"M", big.NewRat(1000*1000, 1)},
+		{"G", big.NewRat(1000*1000*1000, 1)},
+		{"T", big.NewRat(1000*1000*1000*1000, 1)},
+		{"P", big.NewRat(1000*1000*1000*1000*1000, 1)},
+		{"E", big.NewRat(1000*1000*1000*1000*1000*1000, 1)},
+		// Milli
+		{"m", big.NewRat(1, 1000)},
+	}
+
+	for _, sf := range suffixes {
+		if strings.HasSuffix(s, sf.suffix) {
+			rat, err := parseDecimalRat(strings.TrimSuffix(s, sf.suffix))
+			if err != nil {
+				return Quantity{}, err
+			}
+			if rat.Sign() < 0 {
+				return Quantity{}, fmt.Errorf("negative value")
+			}
+			rat.Mul(rat, sf.multiplier)
+			return Quantity{rat: rat}, nil
+		}
+	}
+
+	// No suffix: a raw number, optionally in scientific notation.
+	rat, err := parseDecimalRat(s)
+	if err != nil {
+		return Quantity{}, err
+	}
+	if rat.Sign() < 0 {
+		return Quantity{}, fmt.Errorf("negative value")
+	}
+	return Quantity{rat: rat}, nil
+}
+
+// hasExponent reports whether s has a DecimalExponent-style exponent
+// (e.g. the "e-3" in "1234e-3"), as opposed to an E/Ei suffix.
+func hasExponent(s string) bool {
+	i := strings.IndexAny(s, "eE")
+	if i < 0 || i+1 >= len(s) {
+		return false
+	}
+	c := s[i+1]
+	return c == '-' || c == '+' || (c >= '0' && c <= '9')
+}
+
+// parseDecimalRat parses a plain decimal number, with an optional sign
+// and optional exponent, into an exact big.Rat. Unlike strconv.ParseFloat
+// it never rounds through float64, so values like "12075408" or
+// "1234e-3" keep their full precision.
+func parseDecimalRat(s string) (*big.Rat, error) {
+	orig := s
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg, s = true, s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	mantissa := s
+	exp := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa = s[:i]
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid exponent in %q", orig)
+		}
+		exp = e
+	}
+
+	intPart, fracPart := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		intPart, fracPart = mantissa[:i], mantissa[i+1:]
+	}
+	if intPart == "" && fracPart == "" {
+		return nil, fmt.Errorf("invalid number %q", orig)
+	}
+
+	digits := intPart + fracPart
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid number %q", orig)
+		}
+	}
+	num := new(big.Int)
+	if _, ok := num.SetString(digits, 10); !ok {
+		return nil, fmt.Errorf("invalid number %q", orig)
+	}
+	exp -= len(fracPart)
+
+	rat := new(big.Rat).SetInt(num)
+	if exp != 0 {
+		pow := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(absInt(exp))), nil))
+		if exp > 0 {
+			rat.Mul(rat, pow)
+		} else {
+			rat.Quo(rat, pow)
+		}
+	}
+	if neg {
+		rat.Neg(rat)
+	}
+	return rat, nil
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Format parses s as a quantity and renders it according to format and
+// opts (see (Quantity).Format).
+func Format(s string, format string, opts FormatOptions) (string, error) {
+	q, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return q.Format(format, opts)
+}
+
+// Format renders q as:
+//   - "iec" (the default): binary units, e.g. "1.50GiB"
+//   - "si": decimal units, e.g. "1.50GB"
+//   - "canonical": the smallest exact quantity string (see String)
+//   - "raw": bytes, with no unit suffix
+//
+// opts is only consulted by "iec" and "si"; it's ignored for "canonical"
+// and "raw", which have no notion of spacing, grouping, or locale.
+func (q Quantity) Format(format string, opts FormatOptions) (string, error) {
+	switch format {
+	case "", "iec":
+		return q.HumanIEC(opts), nil
+	case "si":
+		return q.HumanSI(opts), nil
+	case "canonical":
+		return q.String(), nil
+	case "raw":
+		return q.Raw(), nil
+	default:
+		return "", fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// canonicalQuantity finds the largest suffix — binary first, then
+// decimal — that divides q exactly, and reports q as "<n><suffix>". If
+// none divides exactly, q is already its own smallest exact
+// representation and is reported as a plain integer (or, for a
+// non-integral q, a 3-decimal-place string).
+func canonicalQuantity(q Quantity) string {
+	if !q.rat.IsInt() {
+		return q.rat.FloatString(3)
+	}
+	n := q.rat.Num()
+
+	// Zero is divisible by every suffix's divisor, so the loop below
+	// would otherwise pick the largest one (Ei) — longer than, and
+	// wrong next to, its own smallest exact representation, "0".
+	if n.Sign() == 0 {
+		return "0"
+	}
+
+	binarySuffixes := []struct {
+		suffix string
+		exp    int64
+	}{
+		{"Ei", 6}, {"Pi", 5}, {"Ti", 4}, {"Gi", 3}, {"Mi", 2}, {"Ki", 1},
+	}
+	for _, bs := range binarySuffixes {
+		div := new(big.Int).Exp(big.NewInt(1024), big.NewInt(bs.exp), nil)
+		quo, rem := new(big.Int).QuoRem(n, div, new(big.Int))
+		if rem.Sign() == 0 {
+			return quo.String() + bs.suffix
+		}
+	}
+
+	decimalSuffixes := []struct {
+		suffix string
+		exp    int64
+	}{
+		{"E", 18}, {"P", 15}, {"T", 12}, {"G", 9}, {"M", 6}, {"k", 3},
+	}
+	for _, ds := range decimalSuffixes {
+		div := new(big.Int).Exp(big.NewInt(10), big.NewInt(ds.exp), nil)
+		quo, rem := new(big.Int).QuoRem(n, div, new(big.Int))
+		if rem.Sign() == 0 {
+			return quo.String() + ds.suffix
+		}
+	}
+
+	return n.String()
+}
@@ -0,0 +1,522 @@
+package kfmt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Rewriter rewrites quantity-shaped values found in structured input —
+// JSON, NDJSON, YAML, or kubectl tabular output — leaving everything
+// else untouched.
+type Rewriter struct {
+	// Fields selects which values to rewrite. Each entry is either a
+	// bare field name ("usedBytes"), which matches a field with that
+	// name at any depth, or a dotted JSONPath-style selector
+	// ("spec.resources.requests.memory", "status.*.usedBytes",
+	// "items[*].capacity"), which matches an exact path. "*" matches
+	// any single object key, and "name[*]" matches every element of
+	// the array held in field "name". Unused by RewriteTable, which
+	// recognizes its own set of columns.
+	Fields []string
+	// Format controls how matched quantities are rendered: "iec"
+	// (the default), "si", "canonical", or "raw".
+	Format string
+	// Options controls spacing, grouping, precision, forced unit, and
+	// locale for "iec" and "si" Format values; see FormatOptions.
+	Options FormatOptions
+	// CountFields selects plain numeric fields (replicas, restartCount,
+	// observedGeneration) to render with a thousands separator via
+	// Comma, using the same selector syntax as Fields.
+	CountFields []string
+	// OrdinalFields selects integer fields to render as English
+	// ordinals via Ordinal ("1" -> "1st"), using the same selector
+	// syntax as Fields.
+	OrdinalFields []string
+}
+
+func (r Rewriter) format() string {
+	if r.Format == "" {
+		return "iec"
+	}
+	return r.Format
+}
+
+// renderConfig bundles the format and options rewriteValue and its
+// helpers need to render a matched quantity leaf, so they don't have to
+// carry both as separate parameters through every level of recursion.
+type renderConfig struct {
+	format string
+	opts   FormatOptions
+}
+
+// rewriteRules bundles the three independent selector sets a Rewriter
+// walk checks a leaf's path against — quantities, plain counts, and
+// ordinals — along with the quantity renderConfig, so the tree-walking
+// helpers only need to carry one argument.
+type rewriteRules struct {
+	quantity []fieldSelector
+	count    []fieldSelector
+	ordinal  []fieldSelector
+	cfg      renderConfig
+}
+
+// RewriteJSON rewrites quantity-shaped values matched by r.Fields,
+// plain numeric values matched by r.CountFields, and integer values
+// matched by r.OrdinalFields, all into human-readable form.
+//
+// Unlike a text-based rewrite, this decodes the document with
+// encoding/json (UseNumber, so unquoted numerics round-trip without going
+// through float64) and walks the resulting tree, so escaped quotes,
+// identically-named fields at unrelated depths, and array traversal are
+// all handled correctly. Key order and every value, matched or not, are
+// preserved; only matched leaves change. The document is however fully
+// re-encoded, so the original whitespace and indentation are not: output
+// is always compact JSON with a single space after "," and ":", even if
+// input was pretty-printed.
+func (r Rewriter) RewriteJSON(input string) string {
+	if len(r.Fields) == 0 && len(r.CountFields) == 0 && len(r.OrdinalFields) == 0 {
+		return input
+	}
+
+	dec := json.NewDecoder(strings.NewReader(input))
+	dec.UseNumber()
+	root, err := decodeOrdered(dec)
+	if err != nil {
+		return input
+	}
+
+	rules := rewriteRules{
+		quantity: parseFieldSelectors(r.Fields),
+		count:    parseFieldSelectors(r.CountFields),
+		ordinal:  parseFieldSelectors(r.OrdinalFields),
+		cfg:      renderConfig{format: r.format(), opts: r.Options},
+	}
+	root = rewriteValue(root, nil, rules)
+
+	var sb strings.Builder
+	encodeOrdered(root, &sb)
+	return sb.String()
+}
+
+// RewriteYAML converts input (e.g. the output of `kubectl get pvc -o
+// yaml`) to JSON and runs it through RewriteJSON, so callers can pipe
+// YAML directly into kfmt instead of pre-massaging it with a separate
+// YAML-to-JSON tool.
+func (r Rewriter) RewriteYAML(input []byte) (string, error) {
+	jsonBytes, err := yaml.YAMLToJSON(input)
+	if err != nil {
+		return "", fmt.Errorf("parsing YAML: %w", err)
+	}
+	return r.RewriteJSON(string(jsonBytes)), nil
+}
+
+// StreamNDJSON reads line-delimited JSON from src — one object per line,
+// as produced by `kubectl get -o json | jq -c '.items[]'` or by
+// controller logs — and rewrites each line independently with
+// RewriteJSON, writing (and flushing, since dst is written to directly
+// rather than through a buffered writer) one record at a time so the
+// output can be tailed.
+func (r Rewriter) StreamNDJSON(src io.Reader, dst io.Writer) error {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(dst, r.RewriteJSON(line)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// orderedMap is a JSON object that remembers the order its keys were
+// decoded in, since encoding/json's map[string]interface{} does not.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// decodeOrdered decodes the next JSON value from dec, preserving object
+// key order and using json.Number for numerics (dec must have UseNumber
+// enabled).
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil // string, json.Number, bool, or nil
+	}
+
+	switch delim {
+	case '{':
+		om := &orderedMap{values: map[string]interface{}{}}
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := keyTok.(string)
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			om.keys = append(om.keys, key)
+			om.values[key] = val
+		}
+		if _, err := dec.Token(); err != nil { // consume closing '}'
+			return nil, err
+		}
+		return om, nil
+	case '[':
+		arr := []interface{}{}
+		for dec.More() {
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		if _, err := dec.Token(); err != nil { // consume closing ']'
+			return nil, err
+		}
+		return arr, nil
+	default:
+		return nil, fmt.Errorf("unexpected delimiter %q", delim)
+	}
+}
+
+// encodeOrdered serializes v back to compact JSON, writing object keys in
+// their original order and passing json.Number values through verbatim so
+// untouched numerics keep their original precision and formatting.
+func encodeOrdered(v interface{}, sb *strings.Builder) {
+	switch t := v.(type) {
+	case *orderedMap:
+		sb.WriteByte('{')
+		for i, k := range t.keys {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.Write(quoteString(k))
+			sb.WriteString(": ")
+			encodeOrdered(t.values[k], sb)
+		}
+		sb.WriteByte('}')
+	case []interface{}:
+		sb.WriteByte('[')
+		for i, elem := range t {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			encodeOrdered(elem, sb)
+		}
+		sb.WriteByte(']')
+	case string:
+		sb.Write(quoteString(t))
+	case json.Number:
+		sb.WriteString(t.String())
+	case bool:
+		sb.WriteString(strconv.FormatBool(t))
+	case nil:
+		sb.WriteString("null")
+	}
+}
+
+func quoteString(s string) []byte {
+	b, _ := json.Marshal(s)
+	return b
+}
+
+// pathSeg is one dot-separated component of a JSONPath-style selector.
+// array is true for a trailing "[*]", meaning this component must be an
+// array and every element is matched.
+type pathSeg struct {
+	name  string
+	array bool
+}
+
+// fieldSelector is either a legacy bare field name (matches at any depth)
+// or a parsed JSONPath-style selector (matches an exact path).
+type fieldSelector struct {
+	simple string
+	segs   []pathSeg
+}
+
+func parseFieldSelectors(fields []string) []fieldSelector {
+	selectors := make([]fieldSelector, 0, len(fields))
+	for _, f := range fields {
+		if strings.ContainsAny(f, ".[") {
+			selectors = append(selectors, fieldSelector{segs: parseSelectorPath(f)})
+		} else {
+			selectors = append(selectors, fieldSelector{simple: f})
+		}
+	}
+	return selectors
+}
+
+func parseSelectorPath(sel string) []pathSeg {
+	parts := strings.Split(sel, ".")
+	segs := make([]pathSeg, len(parts))
+	for i, p := range parts {
+		if strings.HasSuffix(p, "[*]") {
+			segs[i] = pathSeg{name: strings.TrimSuffix(p, "[*]"), array: true}
+		} else {
+			segs[i] = pathSeg{name: p}
+		}
+	}
+	return segs
+}
+
+// matches reports whether fullPath — the path to a leaf value, with array
+// components written as "name[*]" — is selected by fs.
+func (fs fieldSelector) matches(fullPath []string) bool {
+	if fs.simple != "" {
+		return len(fullPath) > 0 && fullPath[len(fullPath)-1] == fs.simple
+	}
+	if len(fs.segs) != len(fullPath) {
+		return false
+	}
+	for i, seg := range fs.segs {
+		actual := fullPath[i]
+		switch {
+		case seg.array:
+			if actual != seg.name+"[*]" {
+				return false
+			}
+		case seg.name == "*":
+			// wildcard: matches any single component
+		case actual != seg.name:
+			return false
+		}
+	}
+	return true
+}
+
+func selectorsMatch(selectors []fieldSelector, fullPath []string) bool {
+	for _, s := range selectors {
+		if s.matches(fullPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewriteValue walks an object or array, rewriting any leaf whose path
+// matches one of rules' selector sets. path is the path to node itself.
+func rewriteValue(node interface{}, path []string, rules rewriteRules) interface{} {
+	switch n := node.(type) {
+	case *orderedMap:
+		for _, k := range n.keys {
+			childPath := append(append([]string(nil), path...), k)
+			n.values[k] = rewriteChild(n.values[k], childPath, rules)
+		}
+		return n
+	case []interface{}:
+		for i, elem := range n {
+			n[i] = rewriteValue(elem, path, rules)
+		}
+		return n
+	default:
+		return node
+	}
+}
+
+// rewriteChild handles the value held by an object field at path: it
+// descends into objects and arrays, or — for a leaf — rewrites it in
+// place if path matches one of rules' selector sets.
+func rewriteChild(child interface{}, path []string, rules rewriteRules) interface{} {
+	switch c := child.(type) {
+	case []interface{}:
+		arrPath := arrayPath(path)
+		for i, elem := range c {
+			c[i] = rewriteArrayElem(elem, arrPath, rules)
+		}
+		return c
+	case *orderedMap:
+		return rewriteValue(c, path, rules)
+	default:
+		if formatted, ok := tryFormatLeaf(c, path, rules); ok {
+			return formatted
+		}
+		return c
+	}
+}
+
+// rewriteArrayElem is rewriteChild's counterpart for array elements: path
+// already ends in "name[*]" and refers to the element itself.
+func rewriteArrayElem(elem interface{}, path []string, rules rewriteRules) interface{} {
+	switch e := elem.(type) {
+	case *orderedMap:
+		return rewriteValue(e, path, rules)
+	case []interface{}:
+		for i, v := range e {
+			e[i] = rewriteArrayElem(v, path, rules)
+		}
+		return e
+	default:
+		if formatted, ok := tryFormatLeaf(e, path, rules); ok {
+			return formatted
+		}
+		return e
+	}
+}
+
+// arrayPath rewrites path's last component from "name" to "name[*]", for
+// selector matching against elements of the array field "name".
+func arrayPath(path []string) []string {
+	if len(path) == 0 {
+		return path
+	}
+	out := append([]string(nil), path[:len(path)-1]...)
+	return append(out, path[len(path)-1]+"[*]")
+}
+
+// tryFormatLeaf renders v according to whichever of rules' selector
+// sets path matches first — quantity, then count, then ordinal — or
+// reports ok=false if path matches none of them, v isn't a string or
+// json.Number, or the matched renderer can't parse v.
+func tryFormatLeaf(v interface{}, path []string, rules rewriteRules) (string, bool) {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case json.Number:
+		s = t.String()
+	default:
+		return "", false
+	}
+
+	switch {
+	case selectorsMatch(rules.quantity, path):
+		formatted, err := Format(s, rules.cfg.format, rules.cfg.opts)
+		if err != nil {
+			return "", false
+		}
+		return formatted, true
+	case selectorsMatch(rules.count, path):
+		formatted, err := Comma(s)
+		if err != nil {
+			return "", false
+		}
+		return formatted, true
+	case selectorsMatch(rules.ordinal, path):
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return "", false
+		}
+		return Ordinal(n), true
+	default:
+		return "", false
+	}
+}
+
+// tableColumn is a single column of kubectl `get` tabular output: its
+// header name and the byte offset its data starts at. kubectl's
+// text/tabwriter-based printer left-aligns every row to these offsets,
+// so the header line is enough to locate each row's cells.
+type tableColumn struct {
+	name  string
+	start int
+}
+
+var tableTokenRe = regexp.MustCompile(`\S+`)
+
+// tableQuantityColumns are the kubectl column names RewriteTable will
+// rewrite.
+var tableQuantityColumns = map[string]bool{
+	"CAPACITY": true,
+	"REQUESTS": true,
+	"LIMITS":   true,
+	"MEMORY":   true,
+	"STORAGE":  true,
+}
+
+func tableColumns(header string) []tableColumn {
+	matches := tableTokenRe.FindAllStringIndex(header, -1)
+	cols := make([]tableColumn, len(matches))
+	for i, m := range matches {
+		cols[i] = tableColumn{name: header[m[0]:m[1]], start: m[0]}
+	}
+	return cols
+}
+
+// RewriteTable rewrites CAPACITY/REQUESTS/LIMITS/MEMORY/STORAGE columns
+// in kubectl `get` tabular output, preserving the original column
+// alignment: the header line's column offsets are used to locate each
+// row's cells, and rewritten cells are space-padded back out to their
+// original width so later columns don't shift. A rewritten value wider
+// than its original cell (e.g. under --format=raw or --group) can't be
+// padded back to width, so it's given a single separating space instead
+// — later columns shift, but never glue onto the rewritten value.
+// r.Fields is unused.
+func (r Rewriter) RewriteTable(input string) string {
+	lines := strings.Split(input, "\n")
+	if len(lines) == 0 {
+		return input
+	}
+	cols := tableColumns(lines[0])
+	if len(cols) == 0 {
+		return input
+	}
+
+	cfg := renderConfig{format: r.format(), opts: r.Options}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "" {
+			continue
+		}
+		lines[i] = rewriteTableRow(lines[i], cols, cfg)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func rewriteTableRow(line string, cols []tableColumn, cfg renderConfig) string {
+	out := line
+	// Rewrite right-to-left so replacing one column never invalidates
+	// the byte offsets of the columns still to be processed.
+	for i := len(cols) - 1; i >= 0; i-- {
+		col := cols[i]
+		if !tableQuantityColumns[strings.ToUpper(col.name)] || col.start >= len(out) {
+			continue
+		}
+		end := len(out)
+		if i+1 < len(cols) && cols[i+1].start < end {
+			end = cols[i+1].start
+		}
+
+		field := out[col.start:end]
+		value := strings.TrimSpace(field)
+		formatted, err := Format(value, cfg.format, cfg.opts)
+		if err != nil {
+			continue
+		}
+
+		// Pad out to the full cell width (not just the trimmed token's
+		// width) so a following column's start offset doesn't shift.
+		// The last column has nothing to stay aligned with, so there's
+		// no need to pad it back out.
+		width := len(field)
+		if i == len(cols)-1 {
+			width = len(strings.TrimRight(field, " "))
+		}
+		if pad := width - len(formatted); pad > 0 {
+			formatted += strings.Repeat(" ", pad)
+		} else if i < len(cols)-1 {
+			// formatted overran its cell's width; fall out of
+			// alignment rather than glue onto the next column.
+			formatted += " "
+		}
+		out = out[:col.start] + formatted + out[end:]
+	}
+	return out
+}